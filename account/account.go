@@ -0,0 +1,109 @@
+// Package account defines per-mailbox configuration for autoreplygo, loaded
+// either from the ACCOUNTS_JSON environment variable or an accounts.json
+// file, so a single process can run auto-replies for many inboxes at once.
+package account
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Account holds everything needed to run one mailbox's auto-reply loop.
+type Account struct {
+	Name      string `json:"name"`
+	EmailUser string `json:"email_user"`
+	EmailPass string `json:"email_pass"`
+	SMTPHost  string `json:"smtp_host"`
+	SMTPPort  int    `json:"smtp_port"`
+	IMAPHost  string `json:"imap_host"`
+	IMAPPort  int    `json:"imap_port"`
+	HourStart int    `json:"hour_start"`
+	HourEnd   int    `json:"hour_end"`
+	Timezone  string `json:"timezone"`
+
+	RulesFile          string `json:"rules_file"`
+	DedupDB            string `json:"dedup_db"`
+	DedupWindowMinutes int    `json:"dedup_window_minutes"`
+}
+
+// Load reads the account list from the ACCOUNTS_JSON environment variable.
+// If its value looks like a JSON array it's parsed inline; otherwise it's
+// treated as a path to a JSON file. With ACCOUNTS_JSON unset, it falls back
+// to reading "accounts.json" from the working directory.
+func Load() ([]Account, error) {
+	raw := strings.TrimSpace(os.Getenv("ACCOUNTS_JSON"))
+
+	var data []byte
+	switch {
+	case strings.HasPrefix(raw, "["):
+		data = []byte(raw)
+	case raw != "":
+		b, err := os.ReadFile(raw)
+		if err != nil {
+			return nil, fmt.Errorf("read accounts file %s: %w", raw, err)
+		}
+		data = b
+	default:
+		b, err := os.ReadFile("accounts.json")
+		if err != nil {
+			return nil, fmt.Errorf("read accounts file accounts.json: %w", err)
+		}
+		data = b
+	}
+
+	var accounts []Account
+	if err := json.Unmarshal(data, &accounts); err != nil {
+		return nil, fmt.Errorf("parse accounts: %w", err)
+	}
+	if len(accounts) == 0 {
+		return nil, fmt.Errorf("no accounts configured")
+	}
+
+	for i := range accounts {
+		accounts[i].applyDefaults()
+		if err := accounts[i].validate(); err != nil {
+			return nil, fmt.Errorf("account %d: %w", i, err)
+		}
+	}
+
+	return accounts, nil
+}
+
+func (a *Account) applyDefaults() {
+	if a.SMTPPort == 0 {
+		a.SMTPPort = 465
+	}
+	if a.IMAPPort == 0 {
+		a.IMAPPort = 993
+	}
+	if a.HourEnd == 0 {
+		a.HourEnd = 24
+	}
+	if a.Timezone == "" {
+		a.Timezone = "Asia/Jakarta"
+	}
+	if a.RulesFile == "" {
+		a.RulesFile = "rules.yml"
+	}
+	if a.DedupDB == "" {
+		a.DedupDB = a.Name + ".db"
+	}
+	if a.DedupWindowMinutes == 0 {
+		a.DedupWindowMinutes = 24 * 60
+	}
+}
+
+func (a *Account) validate() error {
+	if a.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if a.EmailUser == "" || a.EmailPass == "" {
+		return fmt.Errorf("email_user and email_pass are required")
+	}
+	if a.IMAPHost == "" || a.SMTPHost == "" {
+		return fmt.Errorf("imap_host and smtp_host are required")
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package account
+
+import "testing"
+
+func TestLoadFromInlineJSON(t *testing.T) {
+	t.Setenv("ACCOUNTS_JSON", `[{"name":"gaspro","email_user":"a@example.com","email_pass":"secret","smtp_host":"smtp.example.com","imap_host":"imap.example.com"}]`)
+
+	accounts, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(accounts) != 1 {
+		t.Fatalf("len(accounts) = %d, want 1", len(accounts))
+	}
+
+	a := accounts[0]
+	if a.Name != "gaspro" {
+		t.Errorf("Name = %q, want %q", a.Name, "gaspro")
+	}
+	if a.SMTPPort != 465 {
+		t.Errorf("SMTPPort = %d, want 465 (default)", a.SMTPPort)
+	}
+	if a.IMAPPort != 993 {
+		t.Errorf("IMAPPort = %d, want 993 (default)", a.IMAPPort)
+	}
+	if a.HourEnd != 24 {
+		t.Errorf("HourEnd = %d, want 24 (default)", a.HourEnd)
+	}
+	if a.Timezone != "Asia/Jakarta" {
+		t.Errorf("Timezone = %q, want %q (default)", a.Timezone, "Asia/Jakarta")
+	}
+	if a.RulesFile != "rules.yml" {
+		t.Errorf("RulesFile = %q, want %q (default)", a.RulesFile, "rules.yml")
+	}
+	if a.DedupDB != "gaspro.db" {
+		t.Errorf("DedupDB = %q, want %q (default)", a.DedupDB, "gaspro.db")
+	}
+	if a.DedupWindowMinutes != 24*60 {
+		t.Errorf("DedupWindowMinutes = %d, want %d (default)", a.DedupWindowMinutes, 24*60)
+	}
+}
+
+func TestLoadRejectsEmptyList(t *testing.T) {
+	t.Setenv("ACCOUNTS_JSON", `[]`)
+
+	if _, err := Load(); err == nil {
+		t.Fatalf("expected an error for an empty accounts list")
+	}
+}
+
+func TestLoadRejectsInvalidJSON(t *testing.T) {
+	t.Setenv("ACCOUNTS_JSON", `not json`)
+
+	if _, err := Load(); err == nil {
+		t.Fatalf("expected an error for invalid JSON")
+	}
+}
+
+func TestLoadSurfacesValidationError(t *testing.T) {
+	t.Setenv("ACCOUNTS_JSON", `[{"email_user":"a@example.com","email_pass":"secret","smtp_host":"smtp.example.com","imap_host":"imap.example.com"}]`)
+
+	if _, err := Load(); err == nil {
+		t.Fatalf("expected an error for an account missing a name")
+	}
+}
+
+func TestApplyDefaultsLeavesExplicitValuesAlone(t *testing.T) {
+	a := Account{
+		Name:               "custom",
+		SMTPPort:           587,
+		IMAPPort:           143,
+		HourEnd:            17,
+		Timezone:           "UTC",
+		RulesFile:          "custom.yml",
+		DedupDB:            "custom.db",
+		DedupWindowMinutes: 30,
+	}
+	a.applyDefaults()
+
+	if a.SMTPPort != 587 || a.IMAPPort != 143 || a.HourEnd != 17 || a.Timezone != "UTC" ||
+		a.RulesFile != "custom.yml" || a.DedupDB != "custom.db" || a.DedupWindowMinutes != 30 {
+		t.Errorf("applyDefaults() overwrote explicit values: %+v", a)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		account Account
+		wantErr bool
+	}{
+		{
+			name: "valid account",
+			account: Account{
+				Name:      "gaspro",
+				EmailUser: "a@example.com",
+				EmailPass: "secret",
+				SMTPHost:  "smtp.example.com",
+				IMAPHost:  "imap.example.com",
+			},
+			wantErr: false,
+		},
+		{
+			name:    "missing name",
+			account: Account{EmailUser: "a@example.com", EmailPass: "secret", SMTPHost: "smtp.example.com", IMAPHost: "imap.example.com"},
+			wantErr: true,
+		},
+		{
+			name:    "missing email credentials",
+			account: Account{Name: "gaspro", SMTPHost: "smtp.example.com", IMAPHost: "imap.example.com"},
+			wantErr: true,
+		},
+		{
+			name:    "missing hosts",
+			account: Account{Name: "gaspro", EmailUser: "a@example.com", EmailPass: "secret"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.account.validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
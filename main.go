@@ -1,42 +1,48 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"log"
 	"os"
-	"regexp"
+	"os/signal"
 	"strconv"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/emersion/go-imap"
+	"github.com/emersion/go-imap-idle"
 	"github.com/emersion/go-imap/client"
 	"github.com/emersion/go-message/mail"
 	"github.com/joho/godotenv"
 	"gopkg.in/gomail.v2"
+
+	"github.com/cookiesblack/autoreplygo/account"
+	"github.com/cookiesblack/autoreplygo/metrics"
+	"github.com/cookiesblack/autoreplygo/rules"
+	"github.com/cookiesblack/autoreplygo/rules/extract"
+	"github.com/cookiesblack/autoreplygo/store"
 )
 
 const (
-	logFile  = "logs.txt"
-	timezone = "Asia/Jakarta"
+	logFile = "logs.txt"
+
+	// idleRefreshInterval caps how long we stay in a single IDLE command.
+	// Most IMAP servers drop the connection after ~30 minutes of IDLE, so we
+	// bail out early and re-enter to keep the session alive.
+	idleRefreshInterval = 25 * time.Minute
+
+	minBackoff = 2 * time.Second
+	maxBackoff = 5 * time.Minute
 )
 
 var (
-	emailUser      string
-	emailPass      string
-	smtpHost       string
-	smtpPort       int
-	imapHost       string
-	imapPort       int
-	hourStart      int
-	hourEnd        int
 	debugTimeCheck int
 	prodTimeCheck  int
 	debugMode      bool
-	location       *time.Location
-	showRun        bool = true
-	showInactive   bool = true
 )
 
 func init() {
@@ -44,24 +50,9 @@ func init() {
 		fmt.Println("[x] Warning: No .env file found")
 	}
 
-	emailUser = os.Getenv("EMAIL_USER")
-	emailPass = os.Getenv("EMAIL_PASS")
-	smtpHost = os.Getenv("SMTP_HOST")
-	smtpPort = getEnvAsInt("SMTP_PORT", 465)
-	imapHost = os.Getenv("IMAP_HOST")
-	imapPort = getEnvAsInt("IMAP_PORT", 993)
-	hourStart = getEnvAsInt("HOUR_START", 0)
-	hourEnd = getEnvAsInt("HOUR_END", 24)
 	debugTimeCheck = getEnvAsInt("DEBUG_TIME_CHECK", 30)
 	prodTimeCheck = getEnvAsInt("PROD_TIME_CHECK", 60)
 	debugMode = os.Getenv("DEBUG_MODE") == "true"
-
-	var err error
-	location, err = time.LoadLocation(timezone)
-	if err != nil {
-		log.Printf("Error loading timezone, using UTC: %v", err)
-		location = time.UTC
-	}
 }
 
 func getEnvAsInt(key string, defaultVal int) int {
@@ -72,8 +63,12 @@ func getEnvAsInt(key string, defaultVal int) int {
 	return defaultVal
 }
 
+// writeLog is the process-wide logger, used for messages that aren't tied
+// to any one account (startup banner, shutdown). Per-account activity goes
+// through worker.writeLog instead, which tags each line with the account
+// name.
 func writeLog(message string) {
-	timestamp := time.Now().In(location).Format("2006-01-02 15:04:05")
+	timestamp := time.Now().Format("2006-01-02 15:04:05")
 	logMessage := fmt.Sprintf("[%s] %s\n", timestamp, message)
 
 	fmt.Print(logMessage)
@@ -90,61 +85,331 @@ func writeLog(message string) {
 	}
 }
 
+// worker runs the auto-reply loop for a single account: its own IMAP/SMTP
+// connection, rule set, and dedup store.
+type worker struct {
+	account      account.Account
+	location     *time.Location
+	ruleSet      *rules.Config
+	replyStore   *store.Store
+	dedupWindow  time.Duration
+	showRun      bool
+	showInactive bool
+}
+
+// newWorker loads an account's rules and dedup store and returns a worker
+// ready to run. The caller is responsible for closing the returned worker's
+// store once it's done.
+func newWorker(acc account.Account) (*worker, error) {
+	loc, err := time.LoadLocation(acc.Timezone)
+	if err != nil {
+		log.Printf("[%s] Error loading timezone %s, using UTC: %v", acc.Name, acc.Timezone, err)
+		loc = time.UTC
+	}
+
+	ruleSet, err := rules.Load(acc.RulesFile)
+	if err != nil {
+		return nil, fmt.Errorf("load rules: %w", err)
+	}
+
+	replyStore, err := store.Open(acc.DedupDB)
+	if err != nil {
+		return nil, fmt.Errorf("open store: %w", err)
+	}
+
+	return &worker{
+		account:      acc,
+		location:     loc,
+		ruleSet:      ruleSet,
+		replyStore:   replyStore,
+		dedupWindow:  time.Duration(acc.DedupWindowMinutes) * time.Minute,
+		showRun:      true,
+		showInactive: true,
+	}, nil
+}
+
+// writeLog logs a message tagged with this worker's account name.
+func (w *worker) writeLog(message string) {
+	timestamp := time.Now().In(w.location).Format("2006-01-02 15:04:05")
+	logMessage := fmt.Sprintf("[%s] [%s] %s\n", timestamp, w.account.Name, message)
+
+	fmt.Print(logMessage)
+
+	f, err := os.OpenFile(logFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		log.Printf("[x] Error opening log file: %v", err)
+		return
+	}
+	defer f.Close()
+
+	if _, err := f.WriteString(logMessage); err != nil {
+		log.Printf("[x] Error writing to log file: %v", err)
+	}
+}
+
 // Perbaikan Logika Jam Kerja (Support lintas hari, misal 22:00 s/d 05:00)
-func isActive() bool {
-	now := time.Now().In(location)
+func (w *worker) isActive() bool {
+	now := time.Now().In(w.location)
 	hour := now.Hour()
 
-	if hourStart < hourEnd {
+	if w.account.HourStart < w.account.HourEnd {
 		// Jam kerja normal (misal 08:00 - 17:00)
-		return hour >= hourStart && hour < hourEnd
+		return hour >= w.account.HourStart && hour < w.account.HourEnd
 	} else {
 		// Jam kerja lintas hari (misal 22:00 - 05:00)
-		return hour >= hourStart || hour < hourEnd
+		return hour >= w.account.HourStart || hour < w.account.HourEnd
 	}
 }
 
-func autoReply() {
-	active := isActive()
+// connectIMAP dials and authenticates a fresh IMAP connection for this
+// account.
+func (w *worker) connectIMAP() (*client.Client, error) {
+	addr := fmt.Sprintf("%s:%d", w.account.IMAPHost, w.account.IMAPPort)
+	c, err := client.DialTLS(addr, nil)
+	if err != nil {
+		return nil, fmt.Errorf("dial: %w", err)
+	}
 
-	if active && showRun {
-		showRun = false
-		showInactive = true
-		writeLog("[v] Auto-reply now running")
+	if err := c.Login(w.account.EmailUser, w.account.EmailPass); err != nil {
+		c.Logout()
+		return nil, fmt.Errorf("login: %w", err)
 	}
 
-	if !active && showInactive {
-		showRun = true
-		showInactive = false
-		writeLog("[*] Auto-reply inactive (outside active hours)")
-		return
+	return c, nil
+}
+
+// run is the supervisor: it keeps a mailbox watch session alive for as long
+// as ctx is not cancelled, reconnecting whenever the connection or the watch
+// session dies. The pause before reconnecting doubles (up to maxBackoff) with
+// each consecutive failed connection attempt and restarts at minBackoff once
+// a login succeeds.
+func (w *worker) run(ctx context.Context) {
+	w.writeLog("Worker started")
+	defer w.writeLog("Worker stopped")
+
+	backoff := minBackoff
+
+	for ctx.Err() == nil {
+		c, err := w.connectIMAP()
+		if err != nil {
+			w.writeLog(fmt.Sprintf("[x] IMAP connect failed: %v", err))
+			metrics.IMAPErrors.WithLabelValues(w.account.Name).Inc()
+			backoff = sleepBackoff(ctx, backoff)
+			continue
+		}
+
+		backoff = minBackoff
+
+		if err := w.watchMailbox(ctx, c); err != nil {
+			w.writeLog(fmt.Sprintf("[x] Mailbox watch ended: %v", err))
+			metrics.IMAPErrors.WithLabelValues(w.account.Name).Inc()
+		}
+
+		c.Logout()
+
+		if ctx.Err() == nil {
+			backoff = sleepBackoff(ctx, backoff)
+		}
 	}
+}
 
-	// Jika tidak aktif, hentikan eksekusi di sini
-	if !active {
-		return
+// sleepBackoff waits for d (or until ctx is cancelled) and returns the next
+// backoff duration to use if another failure follows.
+func sleepBackoff(ctx context.Context, d time.Duration) time.Duration {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+	case <-t.C:
 	}
 
-	if debugMode {
-		writeLog("[DEBUG] Checking email")
+	next := d * 2
+	if next > maxBackoff {
+		next = maxBackoff
 	}
+	return next
+}
 
-	c, err := client.DialTLS(fmt.Sprintf("%s:%d", imapHost, imapPort), nil)
-	if err != nil {
-		writeLog(fmt.Sprintf("[x] IMAP Connection Error: %v", err))
-		return
+// watchMailbox selects INBOX, runs an initial check, then watches for new
+// mail via IMAP IDLE, falling back to polling for servers that don't
+// advertise the IDLE capability.
+func (w *worker) watchMailbox(ctx context.Context, c *client.Client) error {
+	if _, err := c.Select("INBOX", false); err != nil {
+		return fmt.Errorf("select INBOX: %w", err)
 	}
-	defer c.Logout()
 
-	if err := c.Login(emailUser, emailPass); err != nil {
-		writeLog(fmt.Sprintf("[x] IMAP Login Error: %v", err))
-		return
+	if err := w.checkMailbox(c); err != nil {
+		w.writeLog(fmt.Sprintf("[x] Initial mailbox check failed: %v", err))
 	}
 
-	_, err = c.Select("INBOX", false)
+	supportsIdle, err := c.Support("IDLE")
 	if err != nil {
-		writeLog(fmt.Sprintf("[x] IMAP Select Error: %v", err))
-		return
+		return fmt.Errorf("check IDLE support: %w", err)
+	}
+
+	if !supportsIdle {
+		w.writeLog("[*] Server does not advertise IDLE, falling back to polling")
+		return w.pollMailbox(ctx, c)
+	}
+
+	return w.idleMailbox(ctx, c)
+}
+
+// idleMailbox keeps the connection in IMAP IDLE, re-checking the mailbox
+// whenever the server reports an update or idleRefreshInterval passes, and
+// periodically refreshing the IDLE command so long-lived servers don't time
+// it out.
+func (w *worker) idleMailbox(ctx context.Context, c *client.Client) error {
+	metrics.IdleState.WithLabelValues(w.account.Name).Set(1)
+	defer metrics.IdleState.WithLabelValues(w.account.Name).Set(0)
+
+	// c.Updates is fed by the client's single reader goroutine, which also
+	// delivers the tagged responses completing our Search/Fetch commands. It
+	// must be drained for the whole lifetime of the connection, not just
+	// while we're waiting in IDLE: if checkMailbox is mid-flight (e.g. a
+	// burst of form submissions) and more than cap(updates) unilateral
+	// updates arrive, the reader goroutine blocks on a full channel and the
+	// connection deadlocks permanently. So drain continuously here and just
+	// raise a one-slot notification flag for the idle-wait loop below.
+	updates := make(chan client.Update, 8)
+	c.Updates = updates
+
+	notify := make(chan struct{}, 1)
+	stopDrain := make(chan struct{})
+	drainDone := make(chan struct{})
+	go func() {
+		defer close(drainDone)
+		for {
+			select {
+			case <-stopDrain:
+				return
+			case upd := <-updates:
+				if _, ok := upd.(*client.MailboxUpdate); !ok {
+					continue
+				}
+				select {
+				case notify <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}()
+	defer func() {
+		close(stopDrain)
+		c.Updates = nil
+		<-drainDone
+	}()
+
+	// IdleWithFallback's pollInterval only drives its polling fallback for
+	// servers that don't support IDLE; watchMailbox already confirmed this
+	// one does, so that path never runs here. The real keepalive timer is
+	// idleClient.LogoutTimeout, which we set explicitly instead.
+	idleClient := idle.NewClient(c)
+	idleClient.LogoutTimeout = idleRefreshInterval
+
+	for {
+		stop := make(chan struct{})
+		idleDone := make(chan error, 1)
+		refresh := time.NewTimer(idleRefreshInterval)
+
+		go func() {
+			idleDone <- idleClient.IdleWithFallback(stop, 0)
+		}()
+
+		select {
+		case <-ctx.Done():
+			close(stop)
+			<-idleDone
+			return nil
+
+		case <-notify:
+			close(stop)
+			<-idleDone
+			if err := w.checkMailbox(c); err != nil {
+				return err
+			}
+
+		case <-refresh.C:
+			// idleRefreshInterval elapsed without new mail: leave IDLE and
+			// re-check before re-entering it.
+			close(stop)
+			if err := <-idleDone; err != nil {
+				return fmt.Errorf("idle: %w", err)
+			}
+			if err := w.checkMailbox(c); err != nil {
+				return err
+			}
+
+		case err := <-idleDone:
+			if err != nil {
+				return fmt.Errorf("idle: %w", err)
+			}
+			// IDLE ended on its own: re-check before re-entering it.
+			if err := w.checkMailbox(c); err != nil {
+				return err
+			}
+		}
+		refresh.Stop()
+	}
+}
+
+// pollMailbox is the legacy fixed-interval fallback for IMAP servers that
+// don't support IDLE.
+func (w *worker) pollMailbox(ctx context.Context, c *client.Client) error {
+	interval := time.Duration(prodTimeCheck) * time.Second
+	if debugMode {
+		interval = time.Duration(debugTimeCheck) * time.Second
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := w.checkMailbox(c); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// checkMailbox searches INBOX for unread, unanswered mail on an already
+// selected connection and processes whatever it finds. It returns an error
+// only for connection-level failures that should trigger a reconnect; any
+// other outcome, including the inactive-hours no-op, counts as a successful
+// cycle for /healthz purposes.
+func (w *worker) checkMailbox(c *client.Client) (err error) {
+	defer func() {
+		if err == nil {
+			metrics.MarkCycleSuccess(w.account.Name)
+		}
+	}()
+
+	active := w.isActive()
+
+	if active && w.showRun {
+		w.showRun = false
+		w.showInactive = true
+		w.writeLog("[v] Auto-reply now running")
+	}
+
+	if !active && w.showInactive {
+		w.showRun = true
+		w.showInactive = false
+		w.writeLog("[*] Auto-reply inactive (outside active hours)")
+		return nil
+	}
+
+	if !active {
+		return nil
+	}
+
+	if debugMode {
+		w.writeLog("[DEBUG] Checking email")
 	}
 
 	criteria := imap.NewSearchCriteria()
@@ -152,18 +417,18 @@ func autoReply() {
 
 	uids, err := c.Search(criteria)
 	if err != nil {
-		writeLog(fmt.Sprintf("[x] IMAP Search Error: %v", err))
-		return
+		return fmt.Errorf("search: %w", err)
 	}
 
 	if len(uids) == 0 {
 		if debugMode {
-			writeLog("[*] No new emails to process")
+			w.writeLog("[*] No new emails to process")
 		}
-		return
+		return nil
 	}
 
-	writeLog(fmt.Sprintf("[v] Found %d new email(s) to process", len(uids)))
+	w.writeLog(fmt.Sprintf("[v] Found %d new email(s) to process", len(uids)))
+	metrics.EmailsFetched.WithLabelValues(w.account.Name).Add(float64(len(uids)))
 
 	seqset := new(imap.SeqSet)
 	seqset.AddNum(uids...)
@@ -179,29 +444,29 @@ func autoReply() {
 	}()
 
 	for msg := range messages {
-		processEmail(c, msg, section)
+		w.processEmail(c, msg, section)
 	}
 
 	if err := <-done; err != nil {
-		writeLog(fmt.Sprintf("[x] Fetch Error: %v", err))
+		return fmt.Errorf("fetch: %w", err)
 	}
 
-	writeLog("--- Auto-reply cycle completed ---")
+	w.writeLog("--- Auto-reply cycle completed ---")
+	return nil
 }
 
-func processEmail(c *client.Client, msg *imap.Message, section *imap.BodySectionName) {
+func (w *worker) processEmail(c *client.Client, msg *imap.Message, section *imap.BodySectionName) {
 	if msg == nil || msg.Envelope == nil {
 		return
 	}
 
 	// Safety: Pastikan email selalu ditandai 'Seen' di akhir
 	defer func() {
-		markAsSeen(c, msg.Uid)
+		w.markAsSeen(c, msg.Uid)
 	}()
 
-	writeLog(fmt.Sprintf("Email UID: %d | Subject: %s", msg.Uid, msg.Envelope.Subject))
+	w.writeLog(fmt.Sprintf("Email UID: %d | Subject: %s", msg.Uid, msg.Envelope.Subject))
 
-	// 1. Ambil Sender
 	var fromEmail, fromName string
 	if len(msg.Envelope.From) > 0 {
 		fromEmail = strings.ToLower(msg.Envelope.From[0].MailboxName + "@" + msg.Envelope.From[0].HostName)
@@ -209,45 +474,17 @@ func processEmail(c *client.Client, msg *imap.Message, section *imap.BodySection
 	}
 
 	subject := msg.Envelope.Subject
+	isFromSelf := strings.ToLower(fromEmail) == strings.ToLower(w.account.EmailUser)
 
-	// Cek apakah email dari akun sendiri
-	isFromSelf := strings.ToLower(fromEmail) == strings.ToLower(emailUser)
-
-	// 2. Loop Prevention: Abaikan jika ini adalah balasan (Re:) dari kita sendiri
-	if isFromSelf && strings.HasPrefix(strings.ToLower(subject), "re:") {
-		writeLog("  [!] IGNORED: Our own auto-reply (loop prevention)")
-		return
-	}
-
-	// 3. Filter Auto-mailer (kecuali jika dari diri sendiri, kita asumsikan itu form notification)
-	if !isFromSelf {
-		if strings.Contains(fromEmail, "no-reply") ||
-			strings.Contains(fromEmail, "noreply") ||
-			strings.Contains(fromEmail, "mailer-daemon") ||
-			strings.Contains(strings.ToLower(subject), "auto") {
-			writeLog("  [!] IGNORED: Auto-mailer detected")
-			return
-		}
-
-		ignoreDomains := []string{"@stripe.com", "@amazon.com.au"}
-		for _, domain := range ignoreDomains {
-			if strings.HasSuffix(fromEmail, domain) {
-				writeLog(fmt.Sprintf("  [!] IGNORED: Domain in ignore list (%s)", fromEmail))
-				return
-			}
-		}
-	}
-
-	// 4. Baca Body Email (Wajib untuk ekstraksi Fluent Form)
 	r := msg.GetBody(section)
 	if r == nil {
-		writeLog("  [!] ERROR: Could not get email body")
+		w.writeLog("  [!] ERROR: Could not get email body")
 		return
 	}
 
 	mr, err := mail.CreateReader(r)
 	if err != nil {
-		writeLog(fmt.Sprintf("  [!] ERROR: Could not parse email: %v", err))
+		w.writeLog(fmt.Sprintf("  [!] ERROR: Could not parse email: %v", err))
 		return
 	}
 
@@ -270,88 +507,162 @@ func processEmail(c *client.Client, msg *imap.Message, section *imap.BodySection
 	}
 	emailBody := bodyBuilder.String()
 
-	// 5. Tentukan Target Balasan
-	targetEmail := ""
-	targetName := "there"
-
-	if isFromSelf {
-		writeLog("  [*] Email from SELF detected. Analyzing as Form Notification...")
-
-		// A. Prioritas 1: Cek Header Reply-To
-		// Fluent Form biasanya menaruh email pelanggan di header Reply-To
-		if len(msg.Envelope.ReplyTo) > 0 {
-			replyToEmail := strings.ToLower(msg.Envelope.ReplyTo[0].MailboxName + "@" + msg.Envelope.ReplyTo[0].HostName)
-			// Pastikan Reply-To bukan diri sendiri
-			if replyToEmail != strings.ToLower(emailUser) {
-				targetEmail = replyToEmail
-				targetName = msg.Envelope.ReplyTo[0].PersonalName
-				writeLog(fmt.Sprintf("  [v] Found customer via Reply-To: %s", targetEmail))
-			}
+	// RFC 3834: never auto-reply to mail that's itself automated, since its
+	// sender won't read the reply and it risks a mail loop with their system.
+	if !isFromSelf && isAutomatedSender(mr.Header) {
+		w.writeLog("  [!] IGNORED: Automated sender (RFC 3834 headers)")
+		return
+	}
+
+	in := rules.Input{
+		FromEmail: fromEmail,
+		FromName:  fromName,
+		Subject:   subject,
+		Headers:   mr.Header,
+		Body:      emailBody,
+		FromSelf:  isFromSelf,
+	}
+
+	rule, matched := w.ruleSet.FirstMatch(in)
+	if !matched {
+		w.writeLog("  [!] IGNORED: No rule matched")
+		return
+	}
+
+	w.writeLog(fmt.Sprintf("  [*] Rule matched: %q (action=%s)", rule.Name, rule.Action))
+
+	switch rule.Action {
+	case rules.ActionIgnore:
+		w.writeLog("  [!] IGNORED: rule action")
+
+	case rules.ActionMark:
+		w.markAsAnswered(c, msg.Uid)
+
+	case rules.ActionForward:
+		if err := w.forwardEmail(rule.ForwardTo, subject, emailBody); err != nil {
+			w.writeLog(fmt.Sprintf("  [!] ERROR forwarding to %s: %v", rule.ForwardTo, err))
+			return
 		}
+		w.writeLog(fmt.Sprintf("  [v] Forwarded to: %s", rule.ForwardTo))
 
-		// B. Prioritas 2: Regex Body HTML (Jika Reply-To gagal atau masih diri sendiri)
+	case rules.ActionReply:
+		targetEmail, targetName, fields := w.resolveReplyTarget(rule, msg, in)
 		if targetEmail == "" {
-			// Regex mencari pola tabel HTML standard Fluent Forms
-			// Mencari: <td> email@address.com </td> setelah header Email
-			emailRegex := regexp.MustCompile(`(?i)<th[^>]*>\s*<strong[^>]*>\s*Email\s*</strong>\s*</th>[\s\S]*?<td[^>]*>\s*([^\s<]+@[^\s<]+)\s*</td>`)
-			if matches := emailRegex.FindStringSubmatch(emailBody); len(matches) > 1 {
-				targetEmail = strings.TrimSpace(matches[1])
-			}
+			w.writeLog("  [!] IGNORED: could not determine reply target")
+			return
+		}
 
-			// Mencari Nama
-			nameRegex := regexp.MustCompile(`(?i)<th[^>]*>\s*<strong[^>]*>\s*Full Name\s*</strong>\s*</th>[\s\S]*?<td[^>]*>\s*([^<]+?)\s*</td>`)
-			if matches := nameRegex.FindStringSubmatch(emailBody); len(matches) > 1 {
-				targetName = strings.TrimSpace(matches[1])
-			}
+		ok, err := w.replyStore.ShouldReply(targetEmail, msg.Envelope.MessageId, w.dedupWindow)
+		if err != nil {
+			w.writeLog(fmt.Sprintf("  [!] ERROR checking dedup store: %v", err))
+			return
+		}
+		if !ok {
+			w.writeLog(fmt.Sprintf("  [!] SUPPRESSED: already replied to %s within %s", targetEmail, w.dedupWindow))
+			metrics.RepliesSuppressed.WithLabelValues(w.account.Name, rule.Name).Inc()
+			return
+		}
 
-			if targetEmail != "" {
-				writeLog(fmt.Sprintf("  [v] Extracted customer via Body Parsing: %s", targetEmail))
-			}
+		if err := w.sendAutoReply(rule, targetEmail, targetName, subject, fields, msg.Envelope.MessageId); err != nil {
+			w.writeLog(fmt.Sprintf("  [!] ERROR sending auto-reply to %s: %v", targetEmail, err))
+			return
 		}
 
-		// C. Jika Gagal Ekstraksi
-		if targetEmail == "" {
-			writeLog("  [!] IGNORED: From self, but failed to extract Customer Email from body/headers.")
-			return // STOP. Jangan balas ke diri sendiri.
+		if err := w.replyStore.RecordReply(targetEmail, msg.Envelope.MessageId); err != nil {
+			w.writeLog(fmt.Sprintf("  [!] ERROR recording reply in dedup store: %v", err))
 		}
 
-	} else {
-		// Email Normal (Bukan dari diri sendiri)
-		targetEmail = fromEmail
-		targetName = fromName
+		metrics.RepliesSent.WithLabelValues(w.account.Name).Inc()
+		w.writeLog(fmt.Sprintf("  [v] Auto-reply sent successfully to: %s", targetEmail))
+		w.markAsAnswered(c, msg.Uid)
+
+	default:
+		w.writeLog(fmt.Sprintf("  [!] IGNORED: unknown rule action %q", rule.Action))
+	}
+}
+
+// isAutomatedSender reports whether headers mark this message as coming
+// from an automated system, per RFC 3834: Auto-Submitted, bulk/list/junk
+// Precedence, a mailing-list List-Id, or X-Auto-Response-Suppress. Replying
+// to these risks a mail loop and the sender won't read it anyway.
+func isAutomatedSender(h mail.Header) bool {
+	if v := strings.TrimSpace(h.Get("Auto-Submitted")); v != "" && !strings.EqualFold(v, "no") {
+		return true
 	}
 
-	if targetName == "" {
-		targetName = "there"
+	switch strings.ToLower(strings.TrimSpace(h.Get("Precedence"))) {
+	case "bulk", "list", "junk":
+		return true
 	}
 
-	// 6. Kirim Auto Reply
-	if err := sendAutoReply(targetEmail, targetName, msg.Envelope.MessageId); err != nil {
-		writeLog(fmt.Sprintf("  [!] ERROR sending auto-reply to %s: %v", targetEmail, err))
-		return
+	if h.Get("List-Id") != "" {
+		return true
 	}
 
-	writeLog(fmt.Sprintf("  [v] Auto-reply sent successfully to: %s", targetEmail))
+	if v := strings.TrimSpace(h.Get("X-Auto-Response-Suppress")); v != "" && !strings.EqualFold(v, "none") {
+		return true
+	}
 
-	// Opsional: Tandai sebagai dijawab di server
-	markAsAnswered(c, msg.Uid)
+	return false
 }
 
-func sendAutoReply(to, name, messageID string) error {
-	m := gomail.NewMessage()
-	m.SetHeader("From", fmt.Sprintf("GasPro Detection <%s>", emailUser))
-	m.SetHeader("To", fmt.Sprintf("%s <%s>", name, to))
-	m.SetHeader("Subject", "Re: We'll Reply Soon As Possible")
+// resolveReplyTarget works out who a reply rule should actually be sent to.
+// For mail from a third party that's simply the sender; for mail from our
+// own account (a form-plugin notification) we prefer the Reply-To header
+// and fall back to the rule's configured extractor.
+func (w *worker) resolveReplyTarget(rule rules.Rule, msg *imap.Message, in rules.Input) (string, string, map[string]string) {
+	if !in.FromSelf {
+		return in.FromEmail, in.FromName, nil
+	}
 
-	body := fmt.Sprintf(`Dear %s,
+	if len(msg.Envelope.ReplyTo) > 0 {
+		replyToEmail := strings.ToLower(msg.Envelope.ReplyTo[0].MailboxName + "@" + msg.Envelope.ReplyTo[0].HostName)
+		if replyToEmail != strings.ToLower(w.account.EmailUser) {
+			w.writeLog(fmt.Sprintf("  [v] Found customer via Reply-To: %s", replyToEmail))
+			return replyToEmail, msg.Envelope.ReplyTo[0].PersonalName, nil
+		}
+	}
 
-Thank you for contacting GasPro Detection.
+	if rule.Extractor == "" {
+		return "", "", nil
+	}
 
-Your message has been received and is currently being reviewed by our team. One of our representatives will get back to you as soon as possible.
+	ext, err := extract.Get(rule.Extractor)
+	if err != nil {
+		w.writeLog(fmt.Sprintf("  [!] ERROR: %v", err))
+		return "", "", nil
+	}
 
-Kind regards,
-GasPro Detection Team`, name)
+	fields, name, email := ext.Extract(in.Body)
+	if email != "" {
+		w.writeLog(fmt.Sprintf("  [v] Extracted customer via %s: %s", rule.Extractor, email))
+	}
+	return email, name, fields
+}
 
+func (w *worker) sendAutoReply(rule rules.Rule, to, name, subject string, fields map[string]string, messageID string) error {
+	if name == "" {
+		name = "there"
+	}
+
+	body, err := rules.RenderBody(rule.Template, rules.TemplateData{
+		Name:            name,
+		Subject:         subject,
+		ExtractedFields: fields,
+	})
+	if err != nil {
+		return fmt.Errorf("render template: %w", err)
+	}
+
+	m := gomail.NewMessage()
+	m.SetHeader("From", w.account.EmailUser)
+	m.SetHeader("To", fmt.Sprintf("%s <%s>", name, to))
+	m.SetHeader("Subject", "Re: "+subject)
+	// RFC 3834: mark this as an automatic response so well-behaved receivers
+	// (and other auto-responders) won't reply to it or treat it as human mail.
+	m.SetHeader("Auto-Submitted", "auto-replied")
+	m.SetHeader("Precedence", "bulk")
+	m.SetHeader("X-Auto-Response-Suppress", "All")
 	m.SetBody("text/plain", body)
 
 	if messageID != "" {
@@ -359,33 +670,52 @@ GasPro Detection Team`, name)
 		m.SetHeader("References", messageID)
 	}
 
-	d := gomail.NewDialer(smtpHost, smtpPort, emailUser, emailPass)
-	return d.DialAndSend(m)
+	d := gomail.NewDialer(w.account.SMTPHost, w.account.SMTPPort, w.account.EmailUser, w.account.EmailPass)
+	if err := d.DialAndSend(m); err != nil {
+		metrics.SMTPErrors.WithLabelValues(w.account.Name).Inc()
+		return err
+	}
+	return nil
 }
 
-func markAsSeen(c *client.Client, uid uint32) {
+func (w *worker) forwardEmail(to, subject, body string) error {
+	m := gomail.NewMessage()
+	m.SetHeader("From", w.account.EmailUser)
+	m.SetHeader("To", to)
+	m.SetHeader("Subject", "Fwd: "+subject)
+	m.SetBody("text/plain", body)
+
+	d := gomail.NewDialer(w.account.SMTPHost, w.account.SMTPPort, w.account.EmailUser, w.account.EmailPass)
+	if err := d.DialAndSend(m); err != nil {
+		metrics.SMTPErrors.WithLabelValues(w.account.Name).Inc()
+		return err
+	}
+	return nil
+}
+
+func (w *worker) markAsSeen(c *client.Client, uid uint32) {
 	seqset := new(imap.SeqSet)
 	seqset.AddNum(uid)
 	item := imap.FormatFlagsOp(imap.AddFlags, true)
 	flags := []interface{}{imap.SeenFlag}
 	if err := c.UidStore(seqset, item, flags, nil); err != nil {
-		writeLog(fmt.Sprintf("  [x] Failed to mark UID %d as seen: %v", uid, err))
+		w.writeLog(fmt.Sprintf("  [x] Failed to mark UID %d as seen: %v", uid, err))
 	}
 }
 
-func markAsAnswered(c *client.Client, uid uint32) {
+func (w *worker) markAsAnswered(c *client.Client, uid uint32) {
 	seqset := new(imap.SeqSet)
 	seqset.AddNum(uid)
 	item := imap.FormatFlagsOp(imap.AddFlags, true)
 	flags := []interface{}{imap.AnsweredFlag}
 	if err := c.UidStore(seqset, item, flags, nil); err != nil {
-		writeLog(fmt.Sprintf("  [x] Failed to mark UID %d as answered: %v", uid, err))
+		w.writeLog(fmt.Sprintf("  [x] Failed to mark UID %d as answered: %v", uid, err))
 	}
 }
 
 func main() {
 	writeLog("===========================================")
-	writeLog("GasPro Email Auto-Reply Service Started")
+	writeLog("AutoReplyGo Service Started")
 	writeLog("===========================================")
 
 	mode := "PRODUCTION"
@@ -394,23 +724,44 @@ func main() {
 	}
 	writeLog(fmt.Sprintf("Mode: %s", mode))
 
-	checkInterval := prodTimeCheck
-	if debugMode {
-		checkInterval = debugTimeCheck
+	accounts, err := account.Load()
+	if err != nil {
+		log.Fatalf("Error loading accounts: %v", err)
 	}
-
-	writeLog(fmt.Sprintf("Check interval: %d seconds", checkInterval))
-	writeLog(fmt.Sprintf("Timezone: %s", timezone))
-	writeLog(fmt.Sprintf("Active hours: %d:00 - %d:00 WIB", hourStart, hourEnd))
+	writeLog(fmt.Sprintf("Loaded %d account(s)", len(accounts)))
 	writeLog("===========================================\n")
 
-	// Jalankan sekali saat startup
-	autoReply()
+	metricsPort := getEnvAsInt("METRICS_PORT", 9090)
+	staleAfter := 2 * idleRefreshInterval
+	metrics.StartServer(fmt.Sprintf(":%d", metricsPort), staleAfter)
+	writeLog(fmt.Sprintf("Metrics server listening on :%d (/metrics, /healthz)", metricsPort))
 
-	ticker := time.NewTicker(time.Duration(checkInterval) * time.Second)
-	defer ticker.Stop()
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
 
-	for range ticker.C {
-		autoReply()
+	var wg sync.WaitGroup
+	started := 0
+	for _, acc := range accounts {
+		w, err := newWorker(acc)
+		if err != nil {
+			writeLog(fmt.Sprintf("[x] [%s] Error initializing account, skipping: %v", acc.Name, err))
+			continue
+		}
+
+		started++
+		metrics.RegisterAccount(acc.Name)
+		wg.Add(1)
+		go func(w *worker) {
+			defer wg.Done()
+			defer w.replyStore.Close()
+			w.run(ctx)
+		}(w)
 	}
+
+	if started == 0 {
+		log.Fatalf("No accounts initialized successfully")
+	}
+
+	wg.Wait()
+	writeLog("[*] Auto-reply service stopped")
 }
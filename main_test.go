@@ -0,0 +1,79 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/emersion/go-message/mail"
+)
+
+func TestIsAutomatedSender(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    bool
+	}{
+		{
+			name:    "no headers",
+			headers: map[string]string{},
+			want:    false,
+		},
+		{
+			name:    "auto-submitted auto-generated",
+			headers: map[string]string{"Auto-Submitted": "auto-generated"},
+			want:    true,
+		},
+		{
+			name:    "auto-submitted no is treated as not automated",
+			headers: map[string]string{"Auto-Submitted": "no"},
+			want:    false,
+		},
+		{
+			name:    "precedence bulk",
+			headers: map[string]string{"Precedence": "bulk"},
+			want:    true,
+		},
+		{
+			name:    "precedence list",
+			headers: map[string]string{"Precedence": "list"},
+			want:    true,
+		},
+		{
+			name:    "precedence junk",
+			headers: map[string]string{"Precedence": "junk"},
+			want:    true,
+		},
+		{
+			name:    "precedence normal is not automated",
+			headers: map[string]string{"Precedence": "first-class"},
+			want:    false,
+		},
+		{
+			name:    "list-id present",
+			headers: map[string]string{"List-Id": "<announce.example.com>"},
+			want:    true,
+		},
+		{
+			name:    "x-auto-response-suppress set",
+			headers: map[string]string{"X-Auto-Response-Suppress": "All"},
+			want:    true,
+		},
+		{
+			name:    "x-auto-response-suppress none is not automated",
+			headers: map[string]string{"X-Auto-Response-Suppress": "none"},
+			want:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var h mail.Header
+			for k, v := range tt.headers {
+				h.Set(k, v)
+			}
+
+			if got := isAutomatedSender(h); got != tt.want {
+				t.Errorf("isAutomatedSender() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
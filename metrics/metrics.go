@@ -0,0 +1,111 @@
+// Package metrics exposes autoreplygo's Prometheus counters and gauges, plus
+// an HTTP server for /metrics and /healthz, so operators can alert on
+// stalled auto-replies instead of grepping logs.txt.
+package metrics
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	EmailsFetched = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "autoreplygo_emails_fetched_total",
+		Help: "Total number of emails fetched from INBOX.",
+	}, []string{"account"})
+
+	RepliesSent = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "autoreplygo_replies_sent_total",
+		Help: "Total number of auto-replies sent.",
+	}, []string{"account"})
+
+	RepliesSuppressed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "autoreplygo_replies_suppressed_total",
+		Help: "Total number of auto-replies suppressed by the dedup/rate-limit store, by the reply rule that matched.",
+	}, []string{"account", "rule"})
+
+	IMAPErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "autoreplygo_imap_errors_total",
+		Help: "Total number of IMAP connection/command errors.",
+	}, []string{"account"})
+
+	SMTPErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "autoreplygo_smtp_errors_total",
+		Help: "Total number of SMTP send errors.",
+	}, []string{"account"})
+
+	IdleState = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "autoreplygo_idle_state",
+		Help: "1 if the account's IMAP connection is currently in an IDLE watch session, 0 otherwise.",
+	}, []string{"account"})
+
+	LastCycleTimestamp = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "autoreplygo_last_successful_cycle_timestamp_seconds",
+		Help: "Unix timestamp of the account's last successful mailbox check cycle.",
+	}, []string{"account"})
+)
+
+func init() {
+	prometheus.MustRegister(
+		EmailsFetched,
+		RepliesSent,
+		RepliesSuppressed,
+		IMAPErrors,
+		SMTPErrors,
+		IdleState,
+		LastCycleTimestamp,
+	)
+}
+
+var lastCycle = struct {
+	mu sync.Mutex
+	at map[string]time.Time
+}{at: map[string]time.Time{}}
+
+// RegisterAccount records that account exists and is expected to complete
+// cycles, so Healthy can flag it as stale if it never finishes one (bad
+// credentials, unreachable host, stuck behind the reconnect backoff)
+// instead of silently omitting it from the check.
+func RegisterAccount(account string) {
+	lastCycle.mu.Lock()
+	defer lastCycle.mu.Unlock()
+
+	if _, ok := lastCycle.at[account]; !ok {
+		lastCycle.at[account] = time.Time{}
+	}
+}
+
+// MarkCycleSuccess records that account just completed a mailbox check
+// cycle without error, for both the timestamp gauge and the /healthz check.
+func MarkCycleSuccess(account string) {
+	now := time.Now()
+
+	lastCycle.mu.Lock()
+	lastCycle.at[account] = now
+	lastCycle.mu.Unlock()
+
+	LastCycleTimestamp.WithLabelValues(account).Set(float64(now.Unix()))
+}
+
+// Healthy reports whether every registered account has completed a cycle
+// within staleAfter, and that at least one account is registered at all. An
+// account that was registered but has never completed a single successful
+// cycle counts as stale rather than being silently ignored.
+func Healthy(staleAfter time.Duration) bool {
+	lastCycle.mu.Lock()
+	defer lastCycle.mu.Unlock()
+
+	if len(lastCycle.at) == 0 {
+		return false
+	}
+
+	for _, t := range lastCycle.at {
+		if t.IsZero() || time.Since(t) > staleAfter {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,31 @@
+package metrics
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// StartServer launches the /metrics and /healthz HTTP server in the
+// background and returns immediately. staleAfter is how long a cycle
+// timestamp may go unrefreshed before /healthz reports unhealthy.
+func StartServer(addr string, staleAfter time.Duration) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		if !Healthy(staleAfter) {
+			http.Error(w, "stale", http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintln(w, "ok")
+	})
+
+	go func() {
+		if err := http.ListenAndServe(addr, mux); err != nil {
+			log.Printf("[x] Metrics server error: %v", err)
+		}
+	}()
+}
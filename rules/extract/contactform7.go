@@ -0,0 +1,32 @@
+package extract
+
+import (
+	"regexp"
+	"strings"
+)
+
+// ContactForm7 extracts submitter fields from Contact Form 7's plain-text
+// "label: value" notification emails.
+type ContactForm7 struct{}
+
+var cf7FieldRe = regexp.MustCompile(`(?im)^\s*([A-Za-z][\w \-]*)\s*:\s*(.+)$`)
+
+func (ContactForm7) Extract(body string) (map[string]string, string, string) {
+	fields := map[string]string{}
+
+	for _, m := range cf7FieldRe.FindAllStringSubmatch(body, -1) {
+		fields[strings.TrimSpace(m[1])] = strings.TrimSpace(m[2])
+	}
+
+	name := fields["Your Name"]
+	if name == "" {
+		name = fields["Name"]
+	}
+
+	email := fields["Your Email"]
+	if email == "" {
+		email = fields["Email"]
+	}
+
+	return fields, name, email
+}
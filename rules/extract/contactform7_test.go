@@ -0,0 +1,38 @@
+package extract
+
+import "testing"
+
+func TestContactForm7Extract(t *testing.T) {
+	body := `A new message was received from your website contact form.
+
+Your Name: Jane Doe
+Your Email: jane@example.com
+Subject: Inquiry
+Message:
+Hello there!`
+
+	fields, name, email := ContactForm7{}.Extract(body)
+
+	if name != "Jane Doe" {
+		t.Errorf("name = %q, want %q", name, "Jane Doe")
+	}
+	if email != "jane@example.com" {
+		t.Errorf("email = %q, want %q", email, "jane@example.com")
+	}
+	if fields["Subject"] != "Inquiry" {
+		t.Errorf("fields[Subject] = %q, want %q", fields["Subject"], "Inquiry")
+	}
+}
+
+func TestContactForm7ExtractFallbackFieldNames(t *testing.T) {
+	body := "Name: Jane Doe\nEmail: jane@example.com\n"
+
+	_, name, email := ContactForm7{}.Extract(body)
+
+	if name != "Jane Doe" {
+		t.Errorf("name = %q, want %q", name, "Jane Doe")
+	}
+	if email != "jane@example.com" {
+		t.Errorf("email = %q, want %q", email, "jane@example.com")
+	}
+}
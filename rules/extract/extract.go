@@ -0,0 +1,26 @@
+// Package extract pulls structured form-submission fields out of an email
+// body for the various form plugins autoreplygo knows about.
+package extract
+
+import "fmt"
+
+// Extractor turns a form-notification email body into a flat field map,
+// plus the submitter's name and email when the form captured them.
+type Extractor interface {
+	Extract(body string) (fields map[string]string, name, email string)
+}
+
+var registry = map[string]Extractor{
+	"fluentforms":  FluentForms{},
+	"wpforms":      WPForms{},
+	"contactform7": ContactForm7{},
+}
+
+// Get looks up a registered extractor by name.
+func Get(name string) (Extractor, error) {
+	e, ok := registry[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown extractor %q", name)
+	}
+	return e, nil
+}
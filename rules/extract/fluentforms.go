@@ -0,0 +1,33 @@
+package extract
+
+import (
+	"regexp"
+	"strings"
+)
+
+// FluentForms extracts submitter fields from the HTML table Fluent Forms
+// sends in its notification emails.
+type FluentForms struct{}
+
+var (
+	fluentFormsEmailRe = regexp.MustCompile(`(?i)<th[^>]*>\s*<strong[^>]*>\s*Email\s*</strong>\s*</th>[\s\S]*?<td[^>]*>\s*([^\s<]+@[^\s<]+)\s*</td>`)
+	fluentFormsNameRe  = regexp.MustCompile(`(?i)<th[^>]*>\s*<strong[^>]*>\s*Full Name\s*</strong>\s*</th>[\s\S]*?<td[^>]*>\s*([^<]+?)\s*</td>`)
+)
+
+func (FluentForms) Extract(body string) (map[string]string, string, string) {
+	fields := map[string]string{}
+
+	email := ""
+	if m := fluentFormsEmailRe.FindStringSubmatch(body); len(m) > 1 {
+		email = strings.TrimSpace(m[1])
+		fields["Email"] = email
+	}
+
+	name := ""
+	if m := fluentFormsNameRe.FindStringSubmatch(body); len(m) > 1 {
+		name = strings.TrimSpace(m[1])
+		fields["Full Name"] = name
+	}
+
+	return fields, name, email
+}
@@ -0,0 +1,34 @@
+package extract
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFluentFormsExtract(t *testing.T) {
+	body := `
+<table>
+  <tr><th><strong>Full Name</strong></th><td>Jane Doe</td></tr>
+  <tr><th><strong>Email</strong></th><td>jane@example.com</td></tr>
+</table>`
+
+	fields, name, email := FluentForms{}.Extract(body)
+
+	if name != "Jane Doe" {
+		t.Errorf("name = %q, want %q", name, "Jane Doe")
+	}
+	if email != "jane@example.com" {
+		t.Errorf("email = %q, want %q", email, "jane@example.com")
+	}
+	want := map[string]string{"Full Name": "Jane Doe", "Email": "jane@example.com"}
+	if !reflect.DeepEqual(fields, want) {
+		t.Errorf("fields = %+v, want %+v", fields, want)
+	}
+}
+
+func TestFluentFormsExtractNoMatch(t *testing.T) {
+	fields, name, email := FluentForms{}.Extract("plain text body with no table")
+	if name != "" || email != "" || len(fields) != 0 {
+		t.Errorf("expected empty extraction, got fields=%+v name=%q email=%q", fields, name, email)
+	}
+}
@@ -0,0 +1,33 @@
+package extract
+
+import (
+	"regexp"
+	"strings"
+)
+
+// WPForms extracts submitter fields from WPForms' notification emails,
+// which lay out each field as a label followed by its value.
+type WPForms struct{}
+
+var (
+	wpFormsEmailRe = regexp.MustCompile(`(?i)Email\s*</?\w*>[\s\S]*?>\s*([^\s<]+@[^\s<]+)\s*<`)
+	wpFormsNameRe  = regexp.MustCompile(`(?i)Name\s*</?\w*>[\s\S]*?>\s*([^<]+?)\s*<`)
+)
+
+func (WPForms) Extract(body string) (map[string]string, string, string) {
+	fields := map[string]string{}
+
+	email := ""
+	if m := wpFormsEmailRe.FindStringSubmatch(body); len(m) > 1 {
+		email = strings.TrimSpace(m[1])
+		fields["Email"] = email
+	}
+
+	name := ""
+	if m := wpFormsNameRe.FindStringSubmatch(body); len(m) > 1 {
+		name = strings.TrimSpace(m[1])
+		fields["Name"] = name
+	}
+
+	return fields, name, email
+}
@@ -0,0 +1,28 @@
+package extract
+
+import "testing"
+
+func TestWPFormsExtract(t *testing.T) {
+	body := `
+<p><strong>Name</strong>: <span>Jane Doe</span></p>
+<p><strong>Email</strong>: <span>jane@example.com</span></p>`
+
+	fields, name, email := WPForms{}.Extract(body)
+
+	if name != "Jane Doe" {
+		t.Errorf("name = %q, want %q", name, "Jane Doe")
+	}
+	if email != "jane@example.com" {
+		t.Errorf("email = %q, want %q", email, "jane@example.com")
+	}
+	if fields["Name"] != "Jane Doe" || fields["Email"] != "jane@example.com" {
+		t.Errorf("fields = %+v", fields)
+	}
+}
+
+func TestWPFormsExtractNoMatch(t *testing.T) {
+	fields, name, email := WPForms{}.Extract("no labels here at all")
+	if name != "" || email != "" || len(fields) != 0 {
+		t.Errorf("expected empty extraction, got fields=%+v name=%q email=%q", fields, name, email)
+	}
+}
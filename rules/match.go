@@ -0,0 +1,77 @@
+package rules
+
+import (
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/emersion/go-message/mail"
+)
+
+// Input is everything a Rule's Match conditions are evaluated against.
+type Input struct {
+	FromEmail string
+	FromName  string
+	Subject   string
+	Headers   mail.Header
+	Body      string
+	FromSelf  bool
+}
+
+// Matches reports whether in satisfies every condition set on m.
+func (m Match) Matches(in Input) bool {
+	if m.FromDomain != "" {
+		at := strings.LastIndex(in.FromEmail, "@")
+		if at < 0 {
+			return false
+		}
+		domain := strings.ToLower(in.FromEmail[at+1:])
+		ok, err := path.Match(strings.ToLower(m.FromDomain), domain)
+		if err != nil || !ok {
+			return false
+		}
+	}
+
+	if m.FromRegex != "" {
+		re, err := regexp.Compile(m.FromRegex)
+		if err != nil || !re.MatchString(in.FromEmail) {
+			return false
+		}
+	}
+
+	if m.SubjectRegex != "" {
+		re, err := regexp.Compile(m.SubjectRegex)
+		if err != nil || !re.MatchString(in.Subject) {
+			return false
+		}
+	}
+
+	if m.HeaderPresent != "" && in.Headers.Get(m.HeaderPresent) == "" {
+		return false
+	}
+
+	if m.BodyRegex != "" {
+		re, err := regexp.Compile(m.BodyRegex)
+		if err != nil || !re.MatchString(in.Body) {
+			return false
+		}
+	}
+
+	if m.FromSelf != nil && *m.FromSelf != in.FromSelf {
+		return false
+	}
+
+	return true
+}
+
+// FirstMatch walks rules in order and returns the first one whose Match
+// conditions are satisfied by in. The second return value is false if
+// nothing matched.
+func (c *Config) FirstMatch(in Input) (Rule, bool) {
+	for _, r := range c.Rules {
+		if r.Match.Matches(in) {
+			return r, true
+		}
+	}
+	return Rule{}, false
+}
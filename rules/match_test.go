@@ -0,0 +1,120 @@
+package rules
+
+import "testing"
+
+func TestMatchMatches(t *testing.T) {
+	trueVal := true
+	falseVal := false
+
+	tests := []struct {
+		name  string
+		match Match
+		input Input
+		want  bool
+	}{
+		{
+			name:  "from_domain glob matches",
+			match: Match{FromDomain: "stripe.com"},
+			input: Input{FromEmail: "billing@stripe.com"},
+			want:  true,
+		},
+		{
+			name:  "from_domain glob does not match different domain",
+			match: Match{FromDomain: "stripe.com"},
+			input: Input{FromEmail: "billing@example.com"},
+			want:  false,
+		},
+		{
+			name:  "from_domain glob with wildcard subdomain",
+			match: Match{FromDomain: "*.example.com"},
+			input: Input{FromEmail: "notify@mail.example.com"},
+			want:  true,
+		},
+		{
+			name:  "from_regex matches case-insensitively",
+			match: Match{FromRegex: "(?i)no-?reply"},
+			input: Input{FromEmail: "No-Reply@example.com"},
+			want:  true,
+		},
+		{
+			name:  "subject_regex matches",
+			match: Match{SubjectRegex: "(?i)^re:"},
+			input: Input{Subject: "Re: hello"},
+			want:  true,
+		},
+		{
+			name:  "subject_regex does not match",
+			match: Match{SubjectRegex: "(?i)^re:"},
+			input: Input{Subject: "hello"},
+			want:  false,
+		},
+		{
+			name:  "body_regex matches",
+			match: Match{BodyRegex: "invoice"},
+			input: Input{Body: "your invoice is attached"},
+			want:  true,
+		},
+		{
+			name:  "from_self true requires self",
+			match: Match{FromSelf: &trueVal},
+			input: Input{FromSelf: true},
+			want:  true,
+		},
+		{
+			name:  "from_self false requires non-self",
+			match: Match{FromSelf: &falseVal},
+			input: Input{FromSelf: true},
+			want:  false,
+		},
+		{
+			name:  "zero-value match always matches",
+			match: Match{},
+			input: Input{FromEmail: "anyone@example.com"},
+			want:  true,
+		},
+		{
+			name:  "multiple conditions must all hold",
+			match: Match{FromDomain: "example.com", SubjectRegex: "(?i)auto"},
+			input: Input{FromEmail: "bot@example.com", Subject: "Auto notice"},
+			want:  true,
+		},
+		{
+			name:  "multiple conditions fail if one does not hold",
+			match: Match{FromDomain: "example.com", SubjectRegex: "(?i)auto"},
+			input: Input{FromEmail: "bot@example.com", Subject: "hello"},
+			want:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.match.Matches(tt.input); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigFirstMatch(t *testing.T) {
+	cfg := &Config{
+		Rules: []Rule{
+			{Name: "ignore stripe", Match: Match{FromDomain: "stripe.com"}, Action: ActionIgnore},
+			{Name: "catch all", Action: ActionReply, Template: "x.tmpl"},
+		},
+	}
+
+	rule, ok := cfg.FirstMatch(Input{FromEmail: "billing@stripe.com"})
+	if !ok || rule.Name != "ignore stripe" {
+		t.Fatalf("expected first rule to match, got %+v (ok=%v)", rule, ok)
+	}
+
+	rule, ok = cfg.FirstMatch(Input{FromEmail: "someone@example.com"})
+	if !ok || rule.Name != "catch all" {
+		t.Fatalf("expected catch-all rule to match, got %+v (ok=%v)", rule, ok)
+	}
+
+	empty := &Config{}
+	if _, ok := empty.FirstMatch(Input{}); ok {
+		t.Fatalf("expected no match against an empty rule set")
+	}
+}
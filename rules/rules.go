@@ -0,0 +1,75 @@
+// Package rules implements a configurable, ordered rule engine that decides
+// what to do with an incoming email: reply with a rendered template,
+// forward it, mark it as handled, or ignore it outright.
+package rules
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Action is what to do once a rule matches an email.
+type Action string
+
+const (
+	ActionReply   Action = "reply"
+	ActionIgnore  Action = "ignore"
+	ActionForward Action = "forward"
+	ActionMark    Action = "mark"
+)
+
+// Match describes the conditions a Rule checks against an incoming email.
+// A zero-value field is treated as "don't care" and always matches.
+type Match struct {
+	FromDomain    string `yaml:"from_domain"`
+	FromRegex     string `yaml:"from_regex"`
+	SubjectRegex  string `yaml:"subject_regex"`
+	HeaderPresent string `yaml:"header_present"`
+	BodyRegex     string `yaml:"body_regex"`
+	FromSelf      *bool  `yaml:"from_self"`
+}
+
+// Rule is one entry in the ordered rule list. Rules are evaluated top to
+// bottom and the first one whose Match conditions all hold wins.
+type Rule struct {
+	Name      string `yaml:"name"`
+	Match     Match  `yaml:"match"`
+	Action    Action `yaml:"action"`
+	Extractor string `yaml:"extractor"`
+	Template  string `yaml:"template"`
+	ForwardTo string `yaml:"forward_to"`
+}
+
+// Config is the top-level rules file.
+type Config struct {
+	Rules []Rule `yaml:"rules"`
+}
+
+// Load reads and parses a rules file from path.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read rules file: %w", err)
+	}
+
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parse rules file: %w", err)
+	}
+
+	for i, r := range cfg.Rules {
+		if r.Action == "" {
+			return nil, fmt.Errorf("rule %d (%s): action is required", i, r.Name)
+		}
+		if r.Action == ActionReply && r.Template == "" {
+			return nil, fmt.Errorf("rule %d (%s): reply action requires a template", i, r.Name)
+		}
+		if r.Action == ActionForward && r.ForwardTo == "" {
+			return nil, fmt.Errorf("rule %d (%s): forward action requires forward_to", i, r.Name)
+		}
+	}
+
+	return &cfg, nil
+}
@@ -0,0 +1,29 @@
+package rules
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+)
+
+// TemplateData is exposed to reply body templates.
+type TemplateData struct {
+	Name            string
+	Subject         string
+	ExtractedFields map[string]string
+}
+
+// RenderBody parses the template file at path and executes it against data.
+func RenderBody(path string, data TemplateData) (string, error) {
+	tmpl, err := template.ParseFiles(path)
+	if err != nil {
+		return "", fmt.Errorf("parse template %s: %w", path, err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("execute template %s: %w", path, err)
+	}
+
+	return buf.String(), nil
+}
@@ -0,0 +1,114 @@
+// Package store provides a persistent, on-disk record of auto-replies we've
+// sent, so autoreplygo can rate-limit replies per sender and guarantee it
+// never replies to the same message twice, even across restarts.
+package store
+
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+var (
+	sendersBucket  = []byte("senders")
+	messagesBucket = []byte("messages")
+)
+
+// Store is a bbolt-backed dedup and rate-limit cache.
+type Store struct {
+	db *bbolt.DB
+}
+
+// Open opens (creating if necessary) the bbolt database at path.
+func Open(path string) (*Store, error) {
+	db, err := bbolt.Open(path, 0600, &bbolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("open store: %w", err)
+	}
+
+	err = db.Update(func(tx *bbolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(sendersBucket); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucketIfNotExists(messagesBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("init store: %w", err)
+	}
+
+	return &Store{db: db}, nil
+}
+
+// Close releases the underlying database file.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// ShouldReply reports whether we're allowed to auto-reply to sender given
+// messageID: false if we've already replied to this exact Message-ID, or if
+// we replied to this sender within window.
+func (s *Store) ShouldReply(sender, messageID string, window time.Duration) (bool, error) {
+	allowed := true
+
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		if messageID != "" {
+			if v := tx.Bucket(messagesBucket).Get([]byte(messageID)); v != nil {
+				allowed = false
+				return nil
+			}
+		}
+
+		v := tx.Bucket(sendersBucket).Get([]byte(sender))
+		if v == nil {
+			return nil
+		}
+
+		last := decodeTime(v)
+		if time.Since(last) < window {
+			allowed = false
+		}
+		return nil
+	})
+	if err != nil {
+		return false, fmt.Errorf("check reply eligibility: %w", err)
+	}
+
+	return allowed, nil
+}
+
+// RecordReply marks sender (and messageID, if given) as replied-to as of
+// now, so future ShouldReply calls take them into account.
+func (s *Store) RecordReply(sender, messageID string) error {
+	now := encodeTime(time.Now())
+
+	err := s.db.Update(func(tx *bbolt.Tx) error {
+		if err := tx.Bucket(sendersBucket).Put([]byte(sender), now); err != nil {
+			return err
+		}
+		if messageID != "" {
+			if err := tx.Bucket(messagesBucket).Put([]byte(messageID), now); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("record reply: %w", err)
+	}
+
+	return nil
+}
+
+func encodeTime(t time.Time) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, uint64(t.Unix()))
+	return b
+}
+
+func decodeTime(b []byte) time.Time {
+	return time.Unix(int64(binary.BigEndian.Uint64(b)), 0)
+}
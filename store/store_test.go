@@ -0,0 +1,113 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func openTestStore(t *testing.T) *Store {
+	t.Helper()
+
+	s, err := Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+
+	return s
+}
+
+func TestShouldReplyMessageIDDedup(t *testing.T) {
+	s := openTestStore(t)
+
+	if err := s.RecordReply("alice@example.com", "msg-1"); err != nil {
+		t.Fatalf("RecordReply() error = %v", err)
+	}
+
+	// A zero window disables the per-sender rate limit, so only the
+	// Message-ID check can suppress this reply.
+	ok, err := s.ShouldReply("alice@example.com", "msg-1", 0)
+	if err != nil {
+		t.Fatalf("ShouldReply() error = %v", err)
+	}
+	if ok {
+		t.Errorf("expected dedup to suppress a reply to an already-seen Message-ID")
+	}
+}
+
+func TestShouldReplyRateLimitsWithinWindow(t *testing.T) {
+	s := openTestStore(t)
+
+	if err := s.RecordReply("alice@example.com", "msg-1"); err != nil {
+		t.Fatalf("RecordReply() error = %v", err)
+	}
+
+	ok, err := s.ShouldReply("alice@example.com", "msg-2", time.Hour)
+	if err != nil {
+		t.Fatalf("ShouldReply() error = %v", err)
+	}
+	if ok {
+		t.Errorf("expected rate limit to suppress a second reply within the window")
+	}
+}
+
+func TestShouldReplyAllowsAfterWindowElapses(t *testing.T) {
+	s := openTestStore(t)
+
+	if err := s.RecordReply("alice@example.com", "msg-1"); err != nil {
+		t.Fatalf("RecordReply() error = %v", err)
+	}
+
+	ok, err := s.ShouldReply("alice@example.com", "msg-2", 0)
+	if err != nil {
+		t.Fatalf("ShouldReply() error = %v", err)
+	}
+	if !ok {
+		t.Errorf("expected a zero window to never suppress on elapsed time alone")
+	}
+}
+
+func TestShouldReplyIsPerSender(t *testing.T) {
+	s := openTestStore(t)
+
+	if err := s.RecordReply("alice@example.com", "msg-1"); err != nil {
+		t.Fatalf("RecordReply() error = %v", err)
+	}
+
+	ok, err := s.ShouldReply("bob@example.com", "msg-2", time.Hour)
+	if err != nil {
+		t.Fatalf("ShouldReply() error = %v", err)
+	}
+	if !ok {
+		t.Errorf("expected a different sender to be unaffected by alice's rate limit")
+	}
+}
+
+func TestShouldReplyFirstContactIsAllowed(t *testing.T) {
+	s := openTestStore(t)
+
+	ok, err := s.ShouldReply("new@example.com", "msg-1", time.Hour)
+	if err != nil {
+		t.Fatalf("ShouldReply() error = %v", err)
+	}
+	if !ok {
+		t.Errorf("expected first-ever reply to a sender to be allowed")
+	}
+}
+
+func TestShouldReplyWithoutMessageIDStillRateLimits(t *testing.T) {
+	s := openTestStore(t)
+
+	if err := s.RecordReply("alice@example.com", ""); err != nil {
+		t.Fatalf("RecordReply() error = %v", err)
+	}
+
+	ok, err := s.ShouldReply("alice@example.com", "", time.Hour)
+	if err != nil {
+		t.Fatalf("ShouldReply() error = %v", err)
+	}
+	if ok {
+		t.Errorf("expected rate limit to apply even without a Message-ID")
+	}
+}